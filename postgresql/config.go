@@ -0,0 +1,279 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/blang/semver"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type featureName string
+
+const (
+	featurePrivileges featureName = "privileges"
+	featureSchema     featureName = "schema"
+)
+
+// featureSupportedVersions maps a feature to the minimum Postgres server
+// version required to use it.
+var featureSupportedVersions = map[featureName]semver.Version{
+	featurePrivileges: semver.MustParse("9.0.0"),
+	featureSchema:     semver.MustParse("9.0.0"),
+}
+
+// Config holds the connection parameters supplied via the provider block.
+type Config struct {
+	Host               string
+	Port               int
+	Database           string
+	Username           string
+	Password           string
+	SSLMode            string
+	ConnectTimeoutSecs int
+	ApplicationName    string
+	MaxOpenConns       int
+	MaxIdleConns       int
+}
+
+func (c *Config) connStr(database string) string {
+	if database == "" {
+		database = c.Database
+	}
+	return fmt.Sprintf(
+		"host=%s port=%d dbname=%s user=%s password=%s sslmode=%s connect_timeout=%d application_name=%s",
+		connStrQuote(c.Host), c.Port, connStrQuote(database), connStrQuote(c.Username), connStrQuote(c.Password), connStrQuote(c.SSLMode),
+		c.ConnectTimeoutSecs, connStrQuote(c.ApplicationName),
+	)
+}
+
+// connStrQuote quotes s for safe use as a value in a libpq keyword/value
+// connection string, per the rules in
+// https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNSTRING:
+// wrap in single quotes and backslash-escape any embedded backslash or single
+// quote. Without this, a value containing whitespace breaks the DSN, and a
+// value containing another "keyword=value" token can inject/override a
+// later keyword since libpq takes the last occurrence of a duplicate key.
+func connStrQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// poolKey identifies a single pooled *sql.DB. Connections are keyed by
+// (host, port, database, user) since credentials and the target database
+// both affect what a connection can be used for.
+type poolKey struct {
+	host     string
+	port     int
+	database string
+	username string
+}
+
+// Client manages a pool of *sql.DB handles, one per database the provider
+// has been asked to operate on, plus the server version negotiated once and
+// shared by every connection it hands out.
+type Client struct {
+	config Config
+
+	mu    sync.Mutex
+	pools map[poolKey]*sql.DB
+
+	version cachedVersion
+}
+
+// NewClient builds a Client for config. No connections are opened until a
+// database is actually requested via Connect/forDatabase.
+func NewClient(config Config) *Client {
+	return &Client{
+		config: config,
+		pools:  map[poolKey]*sql.DB{},
+	}
+}
+
+// pool returns the pooled *sql.DB for database, opening and configuring one
+// the first time it's requested instead of spawning a fresh connection on
+// every call.
+func (c *Client) pool(database string) (*sql.DB, error) {
+	if database == "" {
+		database = c.config.Database
+	}
+
+	key := poolKey{c.config.Host, c.config.Port, database, c.config.Username}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if db, ok := c.pools[key]; ok {
+		return db, nil
+	}
+
+	db, err := sql.Open("postgres", c.config.connStr(database))
+	if err != nil {
+		return nil, fmt.Errorf("could not open connection to database %q: %w", database, err)
+	}
+
+	db.SetMaxOpenConns(c.config.MaxOpenConns)
+	db.SetMaxIdleConns(c.config.MaxIdleConns)
+
+	c.pools[key] = db
+
+	return db, nil
+}
+
+// serverVersion returns the server version, negotiating it once via the
+// default database's connection and caching the result so featureSupported
+// doesn't re-issue SHOW server_version on every resource operation. Unlike a
+// sync.Once, a failed negotiation (e.g. a transient connection error) is not
+// cached, so the next call retries instead of permanently wedging every
+// subsequent resource operation for the life of the Client.
+func (c *Client) serverVersion() (semver.Version, error) {
+	return c.version.get(func() (semver.Version, error) {
+		db, err := c.pool("")
+		if err != nil {
+			return semver.Version{}, err
+		}
+		return fetchServerVersion(db)
+	})
+}
+
+// cachedVersion caches the first successful result of fetch across calls to
+// get, retrying fetch on every call until one succeeds.
+type cachedVersion struct {
+	mu      sync.Mutex
+	version semver.Version
+	ok      bool
+}
+
+func (c *cachedVersion) get(fetch func() (semver.Version, error)) (semver.Version, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ok {
+		return c.version, nil
+	}
+
+	version, err := fetch()
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	c.version = version
+	c.ok = true
+	return c.version, nil
+}
+
+func fetchServerVersion(db *sql.DB) (semver.Version, error) {
+	var versionString string
+	if err := db.QueryRow("SHOW server_version").Scan(&versionString); err != nil {
+		return semver.Version{}, fmt.Errorf("error fetching server_version: %w", err)
+	}
+
+	version, err := semver.ParseTolerant(versionString)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("could not parse server_version %q: %w", versionString, err)
+	}
+
+	return version, nil
+}
+
+// Connect returns a DBConnection to the provider's configured database.
+func (c *Client) Connect() (*DBConnection, error) {
+	return c.forDatabase(c.config.Database)
+}
+
+// forDatabase returns a pooled DBConnection to database, reusing the
+// existing pool if one was already opened for it rather than spawning an
+// ad-hoc connection per apply.
+func (c *Client) forDatabase(database string) (*DBConnection, error) {
+	db, err := c.pool(database)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := c.serverVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DBConnection{client: db, owner: c, version: version}, nil
+}
+
+// DBConnection is a handle to one of the Client's pooled database
+// connections, along with the server version negotiated for the cluster.
+type DBConnection struct {
+	client  *sql.DB
+	owner   *Client
+	version semver.Version
+}
+
+func (db *DBConnection) featureSupported(feature featureName) bool {
+	minVersion, ok := featureSupportedVersions[feature]
+	if !ok {
+		return false
+	}
+	return db.version.GE(minVersion)
+}
+
+func (db *DBConnection) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.client.Query(query, args...)
+}
+
+func (db *DBConnection) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.client.QueryRow(query, args...)
+}
+
+// forDatabase returns a DBConnection pooled against database, or db itself
+// if database is empty.
+func (db *DBConnection) forDatabase(database string) (*DBConnection, error) {
+	if database == "" {
+		return db, nil
+	}
+	return db.owner.forDatabase(database)
+}
+
+// startTransaction begins a transaction against db, or against database if
+// one is given, routing through the owning Client's connection pool rather
+// than opening a new connection.
+func startTransaction(db *DBConnection, database string) (*sql.Tx, error) {
+	target, err := db.forDatabase(database)
+	if err != nil {
+		return nil, err
+	}
+	return target.client.Begin()
+}
+
+func deferredRollback(txn *sql.Tx) {
+	if err := txn.Rollback(); err != nil && err != sql.ErrTxDone {
+		log.Printf("[WARN] could not rollback transaction: %v", err)
+	}
+}
+
+type pgResourceFunc func(db *DBConnection, d *schema.ResourceData) error
+
+// PGResourceFunc adapts a resource function operating on a *DBConnection into
+// the (*schema.ResourceData, interface{}) error signature the SDK expects.
+func PGResourceFunc(fn pgResourceFunc) func(*schema.ResourceData, interface{}) error {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		client := meta.(*Client)
+		db, err := client.Connect()
+		if err != nil {
+			return err
+		}
+		return fn(db, d)
+	}
+}
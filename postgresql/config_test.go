@@ -0,0 +1,105 @@
+package postgresql
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestClientPoolReusesConnectionPerKey(t *testing.T) {
+	client := NewClient(Config{Host: "localhost", Port: 5432, Database: "postgres", Username: "postgres"})
+
+	dbA1, err := client.pool("a")
+	if err != nil {
+		t.Fatalf("pool(a): %v", err)
+	}
+	dbA2, err := client.pool("a")
+	if err != nil {
+		t.Fatalf("pool(a): %v", err)
+	}
+	if dbA1 != dbA2 {
+		t.Fatal("pool returned a different *sql.DB for the same (host, port, database, user) key")
+	}
+
+	dbB, err := client.pool("b")
+	if err != nil {
+		t.Fatalf("pool(b): %v", err)
+	}
+	if dbA1 == dbB {
+		t.Fatal("pool returned the same *sql.DB for two different databases")
+	}
+}
+
+func TestClientPoolConcurrentAccess(t *testing.T) {
+	client := NewClient(Config{Host: "localhost", Port: 5432, Database: "postgres", Username: "postgres"})
+	databases := []string{"app1", "app2", "app3"}
+
+	results := make([][]interface{}, len(databases))
+	var wg sync.WaitGroup
+	for i, database := range databases {
+		results[i] = make([]interface{}, 20)
+		for j := 0; j < 20; j++ {
+			wg.Add(1)
+			go func(i, j int, database string) {
+				defer wg.Done()
+				db, err := client.pool(database)
+				if err != nil {
+					t.Errorf("pool(%q): %v", database, err)
+					return
+				}
+				results[i][j] = db
+			}(i, j, database)
+		}
+	}
+	wg.Wait()
+
+	for i := range databases {
+		for j := 1; j < len(results[i]); j++ {
+			if results[i][j] != results[i][0] {
+				t.Fatalf("concurrent pool(%q) calls returned different *sql.DB handles", databases[i])
+			}
+		}
+	}
+}
+
+func TestCachedVersionRetriesAfterError(t *testing.T) {
+	var cv cachedVersion
+
+	wantErr := errors.New("connection refused")
+	calls := 0
+	fetch := func() (semver.Version, error) {
+		calls++
+		if calls < 3 {
+			return semver.Version{}, wantErr
+		}
+		return semver.MustParse("12.3.0"), nil
+	}
+
+	if _, err := cv.get(fetch); !errors.Is(err, wantErr) {
+		t.Fatalf("expected error on first call, got %v", err)
+	}
+	if _, err := cv.get(fetch); !errors.Is(err, wantErr) {
+		t.Fatalf("expected error on second call, got %v", err)
+	}
+
+	version, err := cv.get(fetch)
+	if err != nil {
+		t.Fatalf("expected third call to succeed, got %v", err)
+	}
+	if version.String() != "12.3.0" {
+		t.Fatalf("expected version 12.3.0, got %s", version)
+	}
+	if calls != 3 {
+		t.Fatalf("expected fetch to be called 3 times, got %d", calls)
+	}
+
+	// Once cached, fetch must not be invoked again.
+	if version, err := cv.get(fetch); err != nil || version.String() != "12.3.0" {
+		t.Fatalf("expected cached version 12.3.0, got %s, %v", version, err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected fetch not to be called again once cached, got %d calls", calls)
+	}
+}
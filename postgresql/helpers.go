@@ -0,0 +1,34 @@
+package postgresql
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// QueryAble is satisfied by *DBConnection and *sql.Tx alike, so read
+// functions can run either against the pooled connection or inside an
+// in-flight transaction.
+type QueryAble interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// pqQuoteLiteral quotes s as a PostgreSQL string literal using the E'...'
+// escape syntax, so backslashes and single quotes in the value always
+// round-trip safely regardless of the standard_conforming_strings setting.
+func pqQuoteLiteral(s string) string {
+	var b strings.Builder
+	b.WriteString("E'")
+	for _, r := range s {
+		switch r {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString("'")
+	return b.String()
+}
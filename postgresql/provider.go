@@ -0,0 +1,98 @@
+package postgresql
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func Provider() *schema.Provider {
+	return &schema.Provider{
+		Schema: map[string]*schema.Schema{
+			"host": {
+				Type:        schema.TypeString,
+				Required:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PGHOST", nil),
+				Description: "Name of PostgreSQL server address to connect to",
+			},
+			"port": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PGPORT", 5432),
+				Description: "The PostgreSQL port number to connect to at the server host",
+			},
+			"database": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PGDATABASE", "postgres"),
+				Description: "Database to connect to, used to negotiate the server version for feature detection",
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Required:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PGUSER", nil),
+				Description: "PostgreSQL user for login",
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PGPASSWORD", nil),
+				Description: "Password to be used if the PostgreSQL server demands password authentication",
+				Sensitive:   true,
+			},
+			"sslmode": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PGSSLMODE", "prefer"),
+				Description: "This option determines whether or with what priority a secure SSL TCP/IP connection will be negotiated with the PostgreSQL server",
+			},
+			"connect_timeout": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PGCONNECT_TIMEOUT", 180),
+				Description: "Maximum wait for connection, in seconds. Defaults to PGCONNECT_TIMEOUT, or 180 if that is unset. Zero means wait indefinitely",
+			},
+			"application_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "terraform-provider-postgresql",
+				Description: "Application name added to the PostgreSQL connection, used by the Postgres server for logging purposes",
+			},
+			"max_open_connections": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     4,
+				Description: "Maximum number of open connections to the database per (host, port, database, user) pool",
+			},
+			"max_idle_connections": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     4,
+				Description: "Maximum number of idle connections kept open in each connection pool",
+			},
+		},
+
+		ResourcesMap: map[string]*schema.Resource{
+			"postgresql_alter_role":         resourcePostgreSQLAlterRole(),
+			"postgresql_default_privileges": resourcePostgreSQLDefaultPrivileges(),
+			"postgresql_schema":             resourcePostgreSQLSchema(),
+		},
+
+		ConfigureFunc: providerConfigure,
+	}
+}
+
+func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+	config := Config{
+		Host:               d.Get("host").(string),
+		Port:               d.Get("port").(int),
+		Database:           d.Get("database").(string),
+		Username:           d.Get("username").(string),
+		Password:           d.Get("password").(string),
+		SSLMode:            d.Get("sslmode").(string),
+		ConnectTimeoutSecs: d.Get("connect_timeout").(int),
+		ApplicationName:    d.Get("application_name").(string),
+		MaxOpenConns:       d.Get("max_open_connections").(int),
+		MaxIdleConns:       d.Get("max_idle_connections").(int),
+	}
+
+	return NewClient(config), nil
+}
@@ -2,7 +2,6 @@ package postgresql
 
 import (
 	"database/sql"
-	// "encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -12,15 +11,34 @@ import (
 )
 
 const (
-	// roleName       = "role_name"
-	// parameterKey   = "configuration_parameter"
-	// parameterValue = "parameter_value"
-
-	// This returns the role membership for role, grant_role
-	getAlterRoleQuery = `
-SELECT rolname AS ALTER_ROLE, to_json(rolconfig) AS ROLE_PARAMS
-FROM pg_catalog.pg_roles pr
+	alterRoleRoleNameAttr   = "role_name"
+	alterRoleDatabaseAttr   = "database"
+	alterRoleParametersAttr = "parameters"
+
+	// This returns the role-level configuration (GUCs) for a role, e.g.
+	// {search_path=public,foo,statement_timeout=5000}
+	getRoleConfigQuery = `
+SELECT rolconfig
+FROM pg_catalog.pg_roles
 WHERE rolname = $1
+`
+
+	// This returns the per-database configuration set via
+	// ALTER ROLE ... IN DATABASE ... SET ..., joining through pg_roles/pg_database
+	// since pg_db_role_setting only stores oids.
+	getDBRoleConfigQuery = `
+SELECT setconfig
+FROM pg_catalog.pg_db_role_setting drs
+JOIN pg_catalog.pg_roles pr ON pr.oid = drs.setrole
+JOIN pg_catalog.pg_database pd ON pd.oid = drs.setdatabase
+WHERE pr.rolname = $1 AND pd.datname = $2
+`
+
+	// vartype tells us whether a GUC takes a quoted literal or a bare value.
+	getGUCVartypeQuery = `
+SELECT vartype
+FROM pg_catalog.pg_settings
+WHERE name = $1
 `
 )
 
@@ -28,26 +46,27 @@ func resourcePostgreSQLAlterRole() *schema.Resource {
 	return &schema.Resource{
 		Create: PGResourceFunc(resourcePostgreSQLAlterRoleCreate),
 		Read:   PGResourceFunc(resourcePostgreSQLAlterRoleRead),
+		Update: PGResourceFunc(resourcePostgreSQLAlterRoleUpdate),
 		Delete: PGResourceFunc(resourcePostgreSQLAlterRoleDelete),
 
 		Schema: map[string]*schema.Schema{
-			"role_name": {
+			alterRoleRoleNameAttr: {
 				Type:        schema.TypeString,
 				Required:    true,
 				ForceNew:    true,
 				Description: "The name of the role to alter the attributes of",
 			},
-			"parameter_key": {
+			alterRoleDatabaseAttr: {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				ForceNew:    true,
-				Description: "The name of the parameter to alter on the role",
+				Description: "If specified, the role's parameters will only be altered for this database, via ALTER ROLE ... IN DATABASE ...",
 			},
-			"parameter_value": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The value of the parameter which is being set",
+			alterRoleParametersAttr: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "A map of configuration parameters (GUCs) to set on the role, e.g. {search_path = \"public,foo\"}",
 			},
 		},
 	}
@@ -72,18 +91,51 @@ func resourcePostgreSQLAlterRoleCreate(db *DBConnection, d *schema.ResourceData)
 		)
 	}
 
-	txn, err := startTransaction(db.client, "")
+	database := d.Get(alterRoleDatabaseAttr).(string)
+	txn, err := startTransaction(db, database)
 	if err != nil {
 		return err
 	}
 	defer deferredRollback(txn)
 
-	// Reset the role alterations before altering them again.
-	if err = resetAlterRole(txn, d); err != nil {
+	parameters := getAlterRoleParameters(d)
+	if err = setRoleParameters(txn, d, map[string]string{}, parameters); err != nil {
 		return err
 	}
 
-	if err = alterRole(txn, d); err != nil {
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	d.SetId(generateAlterRoleID(d))
+
+	return readAlterRole(db, d)
+}
+
+func resourcePostgreSQLAlterRoleUpdate(db *DBConnection, d *schema.ResourceData) error {
+	if !db.featureSupported(featurePrivileges) {
+		return fmt.Errorf(
+			"postgresql_alter_role resource is not supported for this Postgres version (%s)",
+			db.version,
+		)
+	}
+
+	if !d.HasChange(alterRoleParametersAttr) {
+		return readAlterRole(db, d)
+	}
+
+	database := d.Get(alterRoleDatabaseAttr).(string)
+	txn, err := startTransaction(db, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	oldRaw, newRaw := d.GetChange(alterRoleParametersAttr)
+	oldParameters := stringMapFromResource(oldRaw.(map[string]interface{}))
+	newParameters := stringMapFromResource(newRaw.(map[string]interface{}))
+
+	if err = setRoleParameters(txn, d, oldParameters, newParameters); err != nil {
 		return err
 	}
 
@@ -91,8 +143,6 @@ func resourcePostgreSQLAlterRoleCreate(db *DBConnection, d *schema.ResourceData)
 		return fmt.Errorf("could not commit transaction: %w", err)
 	}
 
-	d.SetId(generateAlterRoleID(d))
-
 	return readAlterRole(db, d)
 }
 
@@ -104,13 +154,15 @@ func resourcePostgreSQLAlterRoleDelete(db *DBConnection, d *schema.ResourceData)
 		)
 	}
 
-	txn, err := startTransaction(db.client, "")
+	database := d.Get(alterRoleDatabaseAttr).(string)
+	txn, err := startTransaction(db, database)
 	if err != nil {
 		return err
 	}
 	defer deferredRollback(txn)
 
-	if err = resetAlterRole(txn, d); err != nil {
+	parameters := getAlterRoleParameters(d)
+	if err = setRoleParameters(txn, d, parameters, map[string]string{}); err != nil {
 		return err
 	}
 
@@ -121,85 +173,166 @@ func resourcePostgreSQLAlterRoleDelete(db *DBConnection, d *schema.ResourceData)
 	return nil
 }
 
+// setRoleParameters diffs oldParameters against newParameters and issues only
+// the necessary SET/RESET statements, instead of resetting everything and
+// reapplying the full desired state on every call.
+func setRoleParameters(txn *sql.Tx, d *schema.ResourceData, oldParameters, newParameters map[string]string) error {
+	role := d.Get(alterRoleRoleNameAttr).(string)
+	database, _ := d.Get(alterRoleDatabaseAttr).(string)
+
+	for key := range oldParameters {
+		if _, stillSet := newParameters[key]; !stillSet {
+			query := createResetAlterRoleQuery(role, database, key)
+			log.Println(query)
+			if _, err := txn.Exec(query); err != nil {
+				return fmt.Errorf("could not execute alter reset query (%s): %w", query, err)
+			}
+		}
+	}
+
+	for key, value := range newParameters {
+		if oldValue, ok := oldParameters[key]; ok && oldValue == value {
+			continue
+		}
+		query, err := createAlterRoleQuery(txn, role, database, key, value)
+		if err != nil {
+			return err
+		}
+		log.Println(query)
+		if _, err := txn.Exec(query); err != nil {
+			return fmt.Errorf("could not execute alter query (%s): %w", query, err)
+		}
+	}
+
+	return nil
+}
+
 func readAlterRole(db QueryAble, d *schema.ResourceData) error {
-	var (
-		roleName       string
-		roleParameters interface{}
-	)
-	//log.Println("Printing out the value of the input", roleName, parameterKey, parameterValue)
+	role := d.Get(alterRoleRoleNameAttr).(string)
+	database, _ := d.Get(alterRoleDatabaseAttr).(string)
 
-	alterRoleID := d.Id()
+	var rawConfig pq.StringArray
+	var err error
 
-	values := []interface{}{
-		&roleName,
-		&roleParameters,
+	if database != "" {
+		err = db.QueryRow(getDBRoleConfigQuery, role, database).Scan(&rawConfig)
+	} else {
+		err = db.QueryRow(getRoleConfigQuery, role).Scan(&rawConfig)
 	}
 
-	err := db.QueryRow(getAlterRoleQuery, d.Get("role_name")).Scan(values...)
 	switch {
 	case err == sql.ErrNoRows:
-		log.Printf("[WARN] PostgreSQL alter role (%q) not found", alterRoleID)
+		log.Printf("[WARN] PostgreSQL alter role for role (%q) not found", role)
 		d.SetId("")
 		return nil
 	case err != nil:
 		return fmt.Errorf("error reading alter role: %w", err)
 	}
-	fmt.Printf("THIS WILL PRINT STUFF %s\n", roleParameters)
-	// roleParameterMap := make(map[string]string)
-	// json.Unmarshal( &roleParmeterMap)
-	d.Set("role_name", roleName)
-	// d.Set("paramter_key", parameterKey)
-	// d.Set("parameter_value", parameterValue)
+
+	parameters, err := parseRoleConfig(rawConfig)
+	if err != nil {
+		return fmt.Errorf("error parsing role config for role %q: %w", role, err)
+	}
+
+	d.Set(alterRoleRoleNameAttr, role)
+	d.Set(alterRoleDatabaseAttr, database)
+	d.Set(alterRoleParametersAttr, parameters)
 
 	d.SetId(generateAlterRoleID(d))
 
 	return nil
 }
 
-func createAlterRoleQuery(d *schema.ResourceData) string {
-	alterRole, _ := d.Get("role_name").(string)
-	alterParameterKey, _ := d.Get("parameter_key").(string)
-	alterParameterValue, _ := d.Get("parameter_value").(string)
+// parseRoleConfig decodes a rolconfig/setconfig text[] (entries look like
+// "search_path=public,foo") into a map, producing a byte-identical
+// representation of what createAlterRoleQuery would have set.
+func parseRoleConfig(rawConfig pq.StringArray) (map[string]string, error) {
+	parameters := make(map[string]string, len(rawConfig))
+	for _, entry := range rawConfig {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("unexpected role configuration entry: %q", entry)
+		}
+		parameters[parts[0]] = parts[1]
+	}
+	return parameters, nil
+}
 
-	query := fmt.Sprintf(
-		"ALTER ROLE %s SET %s TO %s",
-		pq.QuoteIdentifier(alterRole),
-		pq.QuoteIdentifier(alterParameterKey),
-		pq.QuoteIdentifier(alterParameterValue),
-	)
+func getAlterRoleParameters(d *schema.ResourceData) map[string]string {
+	return stringMapFromResource(d.Get(alterRoleParametersAttr).(map[string]interface{}))
+}
 
-	return query
+func stringMapFromResource(raw map[string]interface{}) map[string]string {
+	parameters := make(map[string]string, len(raw))
+	for key, value := range raw {
+		parameters[key] = value.(string)
+	}
+	return parameters
 }
 
-func createResetAlterRoleQuery(d *schema.ResourceData) string {
-	alterRole, _ := d.Get("role_name").(string)
-	alterParameterKey, _ := d.Get("parameter_key").(string)
+// createAlterRoleQuery builds the ALTER ROLE ... SET statement for key, value.
+// The key is always an identifier; the value is quoted as a literal for
+// string/enum GUCs and emitted bare for numeric/boolean ones, since
+// identifier-quoting a value like 'UTC' or 5000 produces invalid SQL (or a
+// mangled value, in the case of passwords).
+func createAlterRoleQuery(db QueryAble, role, database, key, value string) (string, error) {
+	quotedValue, err := quoteGUCValue(db, key, value)
+	if err != nil {
+		return "", err
+	}
 
 	return fmt.Sprintf(
-		"ALTER ROLE %s RESET %s",
-		pq.QuoteIdentifier(alterRole),
-		pq.QuoteIdentifier(alterParameterKey),
-	)
+		"ALTER ROLE %s%s SET %s TO %s",
+		pq.QuoteIdentifier(role),
+		inDatabaseClause(database),
+		pq.QuoteIdentifier(key),
+		quotedValue,
+	), nil
 }
 
-func alterRole(txn *sql.Tx, d *schema.ResourceData) error {
-	query := createAlterRoleQuery(d)
-	log.Println(query)
-	if _, err := txn.Exec(query); err != nil {
-		return fmt.Errorf("could not execute alter query testing message: %w", err)
+// quoteGUCValue looks up the vartype of the GUC named key in pg_settings and
+// quotes value accordingly: bare for the numeric/boolean types, and as a
+// literal (via pqQuoteLiteral) for everything else, including GUCs unknown
+// to this server (e.g. extension-defined ones), where literal quoting is
+// the safe default.
+func quoteGUCValue(db QueryAble, key, value string) (string, error) {
+	var vartype string
+	err := db.QueryRow(getGUCVartypeQuery, key).Scan(&vartype)
+	switch {
+	case err == sql.ErrNoRows:
+		return pqQuoteLiteral(value), nil
+	case err != nil:
+		return "", fmt.Errorf("error looking up vartype for parameter %q: %w", key, err)
 	}
-	return nil
+
+	switch vartype {
+	case "bool", "integer", "real":
+		return value, nil
+	default:
+		return pqQuoteLiteral(value), nil
+	}
+}
+
+func createResetAlterRoleQuery(role, database, key string) string {
+	return fmt.Sprintf(
+		"ALTER ROLE %s%s RESET %s",
+		pq.QuoteIdentifier(role),
+		inDatabaseClause(database),
+		pq.QuoteIdentifier(key),
+	)
 }
 
-func resetAlterRole(txn *sql.Tx, d *schema.ResourceData) error {
-	query := createResetAlterRoleQuery(d)
-	fmt.Println(query)
-	if _, err := txn.Exec(query); err != nil {
-		return fmt.Errorf("could not execute alter reset query (%s): %w", query, err)
+func inDatabaseClause(database string) string {
+	if database == "" {
+		return ""
 	}
-	return nil
+	return fmt.Sprintf(" IN DATABASE %s", pq.QuoteIdentifier(database))
 }
 
 func generateAlterRoleID(d *schema.ResourceData) string {
-	return strings.Join([]string{d.Get("role_name").(string), d.Get("parameter_key").(string), d.Get("parameter_value").(string)}, "_")
+	database, _ := d.Get(alterRoleDatabaseAttr).(string)
+	if database == "" {
+		return d.Get(alterRoleRoleNameAttr).(string)
+	}
+	return strings.Join([]string{d.Get(alterRoleRoleNameAttr).(string), database}, "_")
 }
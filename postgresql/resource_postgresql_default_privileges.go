@@ -0,0 +1,286 @@
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/lib/pq"
+)
+
+const (
+	defPrivilegesOwnerAttr      = "owner"
+	defPrivilegesRoleAttr       = "role"
+	defPrivilegesSchemaAttr     = "schema"
+	defPrivilegesObjectTypeAttr = "object_type"
+	defPrivilegesPrivilegesAttr = "privileges"
+
+	getDefaultPrivilegesQuery = `
+SELECT
+	COALESCE(pg_catalog.pg_get_userbyid(acl.grantee), 'public') AS grantee,
+	acl.privilege_type
+FROM pg_catalog.pg_default_acl da,
+	LATERAL aclexplode(da.defaclacl) AS acl
+WHERE pg_catalog.pg_get_userbyid(da.defaclrole) = $1
+	AND da.defaclobjtype = $2
+	AND CASE WHEN $3 = '' THEN da.defaclnamespace = 0 ELSE da.defaclnamespace = (SELECT oid FROM pg_catalog.pg_namespace WHERE nspname = $3) END
+	AND acl.grantee = (SELECT oid FROM pg_catalog.pg_roles WHERE rolname = $4)
+`
+)
+
+// defaultPrivilegesObjectTypes maps the object_type attribute to the
+// single-character defaclobjtype stored in pg_default_acl and the keyword
+// used in the ALTER DEFAULT PRIVILEGES ... ON <keyword> clause.
+var defaultPrivilegesObjectTypes = map[string]struct {
+	defaclObjType string
+	keyword       string
+}{
+	"table":    {"r", "TABLES"},
+	"sequence": {"S", "SEQUENCES"},
+	"function": {"f", "FUNCTIONS"},
+	"type":     {"T", "TYPES"},
+	"schema":   {"n", "SCHEMAS"},
+}
+
+// defaultPrivilegesValidPrivileges lists the privileges Postgres accepts in
+// an ALTER DEFAULT PRIVILEGES ... ON <object_type> clause, keyed by
+// object_type. privileges is interpolated directly into GRANT/REVOKE SQL
+// text, so this doubles as an allow-list rather than just a usability check.
+var defaultPrivilegesValidPrivileges = map[string]map[string]bool{
+	"table":    {"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "TRUNCATE": true, "REFERENCES": true, "TRIGGER": true, "ALL": true},
+	"sequence": {"SELECT": true, "UPDATE": true, "USAGE": true, "ALL": true},
+	"function": {"EXECUTE": true, "ALL": true},
+	"type":     {"USAGE": true, "ALL": true},
+	"schema":   {"CREATE": true, "USAGE": true, "ALL": true},
+}
+
+func resourcePostgreSQLDefaultPrivileges() *schema.Resource {
+	return &schema.Resource{
+		Create: PGResourceFunc(resourcePostgreSQLDefaultPrivilegesCreate),
+		Read:   PGResourceFunc(resourcePostgreSQLDefaultPrivilegesRead),
+		Delete: PGResourceFunc(resourcePostgreSQLDefaultPrivilegesDelete),
+
+		CustomizeDiff: validateDefaultPrivilegesForObjectType,
+
+		Schema: map[string]*schema.Schema{
+			defPrivilegesOwnerAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Role for which to alter default privileges, i.e. the owner that will create the objects",
+			},
+			defPrivilegesRoleAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Role to which grant the privileges on new objects created by owner",
+			},
+			defPrivilegesSchemaAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Restrict the default privileges to this schema, if specified",
+			},
+			defPrivilegesObjectTypeAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The type of object to which the default privileges apply. One of: table, sequence, function, type, schema",
+				ValidateFunc: func(val interface{}, key string) ([]string, []error) {
+					if _, ok := defaultPrivilegesObjectTypes[val.(string)]; !ok {
+						return nil, []error{fmt.Errorf("%q must be one of table, sequence, function, type or schema, got %q", key, val)}
+					}
+					return nil, nil
+				},
+			},
+			defPrivilegesPrivilegesAttr: {
+				Type:        schema.TypeSet,
+				Required:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The list of privileges to grant by default on objects of object_type created by owner",
+			},
+		},
+	}
+}
+
+// validateDefaultPrivilegesForObjectType rejects privileges that Postgres
+// doesn't accept for the selected object_type (e.g. INSERT on a schema, or
+// anything but EXECUTE/ALL on a function). object_type isn't known until
+// plan time, which a plain ValidateFunc on privileges can't see, so this
+// validates via CustomizeDiff instead.
+func validateDefaultPrivilegesForObjectType(_ context.Context, diff *schema.ResourceDiff, _ interface{}) error {
+	objectType := diff.Get(defPrivilegesObjectTypeAttr).(string)
+	allowed, ok := defaultPrivilegesValidPrivileges[objectType]
+	if !ok {
+		return nil
+	}
+
+	for _, p := range diff.Get(defPrivilegesPrivilegesAttr).(*schema.Set).List() {
+		privilege := strings.ToUpper(p.(string))
+		if !allowed[privilege] {
+			return fmt.Errorf("privilege %q is not valid for object_type %q", p, objectType)
+		}
+	}
+
+	return nil
+}
+
+func resourcePostgreSQLDefaultPrivilegesCreate(db *DBConnection, d *schema.ResourceData) error {
+	if !db.featureSupported(featurePrivileges) {
+		return fmt.Errorf(
+			"postgresql_default_privileges resource is not supported for this Postgres version (%s)",
+			db.version,
+		)
+	}
+
+	txn, err := startTransaction(db, "")
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	query := createDefaultPrivilegesQuery(d, "GRANT")
+	log.Println(query)
+	if _, err := txn.Exec(query); err != nil {
+		return fmt.Errorf("could not grant default privileges: %w", err)
+	}
+
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	d.SetId(generateDefaultPrivilegesID(d))
+
+	return resourcePostgreSQLDefaultPrivilegesReadImpl(db, d)
+}
+
+func resourcePostgreSQLDefaultPrivilegesRead(db *DBConnection, d *schema.ResourceData) error {
+	if !db.featureSupported(featurePrivileges) {
+		return fmt.Errorf(
+			"postgresql_default_privileges resource is not supported for this Postgres version (%s)",
+			db.version,
+		)
+	}
+
+	return resourcePostgreSQLDefaultPrivilegesReadImpl(db, d)
+}
+
+func resourcePostgreSQLDefaultPrivilegesReadImpl(db *DBConnection, d *schema.ResourceData) error {
+	owner := d.Get(defPrivilegesOwnerAttr).(string)
+	role := d.Get(defPrivilegesRoleAttr).(string)
+	schemaName := d.Get(defPrivilegesSchemaAttr).(string)
+	objectType := d.Get(defPrivilegesObjectTypeAttr).(string)
+
+	privileges, err := readDefaultPrivileges(db, owner, role, schemaName, objectType)
+	if err != nil {
+		return err
+	}
+
+	if len(privileges) == 0 {
+		log.Printf("[WARN] No default privileges found for role %q granted by %q, removing from state", role, owner)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set(defPrivilegesOwnerAttr, owner)
+	d.Set(defPrivilegesRoleAttr, role)
+	d.Set(defPrivilegesSchemaAttr, schemaName)
+	d.Set(defPrivilegesObjectTypeAttr, objectType)
+	d.Set(defPrivilegesPrivilegesAttr, privileges)
+	d.SetId(generateDefaultPrivilegesID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLDefaultPrivilegesDelete(db *DBConnection, d *schema.ResourceData) error {
+	if !db.featureSupported(featurePrivileges) {
+		return fmt.Errorf(
+			"postgresql_default_privileges resource is not supported for this Postgres version (%s)",
+			db.version,
+		)
+	}
+
+	txn, err := startTransaction(db, "")
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	query := createDefaultPrivilegesQuery(d, "REVOKE")
+	log.Println(query)
+	if _, err := txn.Exec(query); err != nil {
+		return fmt.Errorf("could not revoke default privileges: %w", err)
+	}
+
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func readDefaultPrivileges(db QueryAble, owner, role, schemaName, objectType string) ([]string, error) {
+	objType := defaultPrivilegesObjectTypes[objectType]
+
+	rows, err := db.Query(getDefaultPrivilegesQuery, owner, objType.defaclObjType, schemaName, role)
+	if err != nil {
+		return nil, fmt.Errorf("error reading default privileges: %w", err)
+	}
+	defer rows.Close()
+
+	var privileges []string
+	for rows.Next() {
+		var grantee, privilege string
+		if err := rows.Scan(&grantee, &privilege); err != nil {
+			return nil, fmt.Errorf("error scanning default privilege row: %w", err)
+		}
+		privileges = append(privileges, privilege)
+	}
+
+	return privileges, rows.Err()
+}
+
+// createDefaultPrivilegesQuery builds the
+// ALTER DEFAULT PRIVILEGES FOR ROLE ... [IN SCHEMA ...] GRANT|REVOKE ... ON
+// <object_type> TO|FROM role statement for verb "GRANT" or "REVOKE".
+func createDefaultPrivilegesQuery(d *schema.ResourceData, verb string) string {
+	owner := d.Get(defPrivilegesOwnerAttr).(string)
+	role := d.Get(defPrivilegesRoleAttr).(string)
+	schemaName := d.Get(defPrivilegesSchemaAttr).(string)
+	objectType := d.Get(defPrivilegesObjectTypeAttr).(string)
+	keyword := defaultPrivilegesObjectTypes[objectType].keyword
+
+	privileges := make([]string, 0)
+	for _, p := range d.Get(defPrivilegesPrivilegesAttr).(*schema.Set).List() {
+		privileges = append(privileges, p.(string))
+	}
+
+	b := bytes.NewBufferString("ALTER DEFAULT PRIVILEGES FOR ROLE ")
+	b.WriteString(pq.QuoteIdentifier(owner))
+
+	if schemaName != "" {
+		fmt.Fprintf(b, " IN SCHEMA %s", pq.QuoteIdentifier(schemaName))
+	}
+
+	switch verb {
+	case "GRANT":
+		fmt.Fprintf(b, " GRANT %s ON %s TO %s", strings.Join(privileges, ", "), keyword, pq.QuoteIdentifier(role))
+	case "REVOKE":
+		fmt.Fprintf(b, " REVOKE %s ON %s FROM %s", strings.Join(privileges, ", "), keyword, pq.QuoteIdentifier(role))
+	}
+
+	return b.String()
+}
+
+func generateDefaultPrivilegesID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(defPrivilegesOwnerAttr).(string),
+		d.Get(defPrivilegesSchemaAttr).(string),
+		d.Get(defPrivilegesObjectTypeAttr).(string),
+		d.Get(defPrivilegesRoleAttr).(string),
+	}, "_")
+}
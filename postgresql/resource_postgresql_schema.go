@@ -0,0 +1,465 @@
+package postgresql
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/lib/pq"
+)
+
+const (
+	schemaNameAttr                  = "name"
+	schemaOwnerAttr                 = "owner"
+	schemaIfNotExistsAttr           = "if_not_exists"
+	schemaDropCascadeAttr           = "drop_cascade"
+	schemaPolicyAttr                = "policy"
+	schemaPolicyRoleAttr            = "role"
+	schemaPolicyCreateAttr          = "create"
+	schemaPolicyCreateWithGrantAttr = "create_with_grant"
+	schemaPolicyUsageAttr           = "usage"
+	schemaPolicyUsageWithGrantAttr  = "usage_with_grant"
+
+	schemaPublicRole = "public"
+
+	getSchemaNameQuery = `
+SELECT pg_catalog.pg_get_userbyid(n.nspowner)
+FROM pg_catalog.pg_namespace n
+WHERE n.nspname = $1
+`
+
+	// Only explodes the schema's actual ACL (no acldefault() fallback), so a
+	// schema with a NULL nspacl (the common case: nothing has ever been
+	// explicitly granted/revoked) yields no rows instead of synthesizing the
+	// owner's implicit CREATE/USAGE and PUBLIC's implicit USAGE as if they
+	// were managed grants.
+	getSchemaPoliciesQuery = `
+SELECT
+	COALESCE(pg_catalog.pg_get_userbyid(acl.grantee), 'public') AS grantee,
+	acl.privilege_type,
+	acl.is_grantable
+FROM pg_catalog.pg_namespace n,
+	LATERAL aclexplode(n.nspacl) AS acl
+WHERE n.nspname = $1
+	AND acl.privilege_type IN ('CREATE', 'USAGE')
+`
+)
+
+func resourcePostgreSQLSchema() *schema.Resource {
+	return &schema.Resource{
+		Create: PGResourceFunc(resourcePostgreSQLSchemaCreate),
+		Read:   PGResourceFunc(resourcePostgreSQLSchemaRead),
+		Update: PGResourceFunc(resourcePostgreSQLSchemaUpdate),
+		Delete: PGResourceFunc(resourcePostgreSQLSchemaDelete),
+
+		Schema: map[string]*schema.Schema{
+			schemaNameAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name of the schema",
+			},
+			schemaOwnerAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The ROLE who owns the schema",
+			},
+			schemaIfNotExistsAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, use the existing schema if it exists",
+			},
+			schemaDropCascadeAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, will also drop all the objects contained in the schema",
+			},
+			schemaPolicyAttr: {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Schema policies to apply",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						schemaPolicyRoleAttr: {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     schemaPublicRole,
+							Description: "Role to grant privileges on the schema to. Defaults to PUBLIC",
+						},
+						schemaPolicyCreateAttr: {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether to grant CREATE on the schema",
+						},
+						schemaPolicyCreateWithGrantAttr: {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether to grant CREATE on the schema and whether the grantee can grant it to others",
+						},
+						schemaPolicyUsageAttr: {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether to grant USAGE on the schema",
+						},
+						schemaPolicyUsageWithGrantAttr: {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether to grant USAGE on the schema and whether the grantee can grant it to others",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLSchemaCreate(db *DBConnection, d *schema.ResourceData) error {
+	if !db.featureSupported(featureSchema) {
+		return fmt.Errorf(
+			"postgresql_schema resource is not supported for this Postgres version (%s)",
+			db.version,
+		)
+	}
+
+	txn, err := startTransaction(db, "")
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	schemaName := d.Get(schemaNameAttr).(string)
+
+	b := bytes.NewBufferString("CREATE SCHEMA ")
+	if d.Get(schemaIfNotExistsAttr).(bool) {
+		b.WriteString("IF NOT EXISTS ")
+	}
+	b.WriteString(pq.QuoteIdentifier(schemaName))
+
+	if owner, ok := d.GetOk(schemaOwnerAttr); ok {
+		fmt.Fprintf(b, " AUTHORIZATION %s", pq.QuoteIdentifier(owner.(string)))
+	}
+
+	log.Println(b.String())
+	if _, err := txn.Exec(b.String()); err != nil {
+		return fmt.Errorf("error creating schema %q: %w", schemaName, err)
+	}
+
+	if err := setSchemaPolicies(txn, d, map[string]schemaPolicy{}); err != nil {
+		return err
+	}
+
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	d.SetId(schemaName)
+
+	return resourcePostgreSQLSchemaReadImpl(db, d)
+}
+
+func resourcePostgreSQLSchemaRead(db *DBConnection, d *schema.ResourceData) error {
+	if !db.featureSupported(featureSchema) {
+		return fmt.Errorf(
+			"postgresql_schema resource is not supported for this Postgres version (%s)",
+			db.version,
+		)
+	}
+
+	return resourcePostgreSQLSchemaReadImpl(db, d)
+}
+
+func resourcePostgreSQLSchemaReadImpl(db *DBConnection, d *schema.ResourceData) error {
+	schemaName := d.Id()
+
+	var owner string
+	err := db.QueryRow(getSchemaNameQuery, schemaName).Scan(&owner)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL schema (%q) not found", schemaName)
+		d.SetId("")
+		return nil
+	case err != nil:
+		return fmt.Errorf("error reading schema: %w", err)
+	}
+
+	policies, err := readSchemaPolicies(db, schemaName, owner)
+	if err != nil {
+		return err
+	}
+
+	d.Set(schemaNameAttr, schemaName)
+	d.Set(schemaOwnerAttr, owner)
+	d.Set(schemaPolicyAttr, schemaPoliciesToSet(policies))
+	d.SetId(schemaName)
+
+	return nil
+}
+
+func resourcePostgreSQLSchemaUpdate(db *DBConnection, d *schema.ResourceData) error {
+	if !db.featureSupported(featureSchema) {
+		return fmt.Errorf(
+			"postgresql_schema resource is not supported for this Postgres version (%s)",
+			db.version,
+		)
+	}
+
+	txn, err := startTransaction(db, "")
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if d.HasChange(schemaNameAttr) {
+		oldName, newName := d.GetChange(schemaNameAttr)
+		query := fmt.Sprintf("ALTER SCHEMA %s RENAME TO %s", pq.QuoteIdentifier(oldName.(string)), pq.QuoteIdentifier(newName.(string)))
+		log.Println(query)
+		if _, err := txn.Exec(query); err != nil {
+			return fmt.Errorf("error renaming schema: %w", err)
+		}
+		d.SetId(newName.(string))
+	}
+
+	if d.HasChange(schemaOwnerAttr) {
+		query := fmt.Sprintf("ALTER SCHEMA %s OWNER TO %s", pq.QuoteIdentifier(d.Id()), pq.QuoteIdentifier(d.Get(schemaOwnerAttr).(string)))
+		log.Println(query)
+		if _, err := txn.Exec(query); err != nil {
+			return fmt.Errorf("error setting schema owner: %w", err)
+		}
+	}
+
+	if d.HasChange(schemaPolicyAttr) {
+		oldPolicyRaw, _ := d.GetChange(schemaPolicyAttr)
+		oldPolicies := schemaPoliciesFromSet(oldPolicyRaw.(*schema.Set))
+		if err := setSchemaPolicies(txn, d, oldPolicies); err != nil {
+			return err
+		}
+	}
+
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return resourcePostgreSQLSchemaReadImpl(db, d)
+}
+
+func resourcePostgreSQLSchemaDelete(db *DBConnection, d *schema.ResourceData) error {
+	if !db.featureSupported(featureSchema) {
+		return fmt.Errorf(
+			"postgresql_schema resource is not supported for this Postgres version (%s)",
+			db.version,
+		)
+	}
+
+	txn, err := startTransaction(db, "")
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	b := bytes.NewBufferString("DROP SCHEMA ")
+	b.WriteString(pq.QuoteIdentifier(d.Id()))
+	if d.Get(schemaDropCascadeAttr).(bool) {
+		b.WriteString(" CASCADE")
+	}
+
+	log.Println(b.String())
+	if _, err := txn.Exec(b.String()); err != nil {
+		return fmt.Errorf("error dropping schema %q: %w", d.Id(), err)
+	}
+
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// schemaPolicy is the resolved set of grants held by a single role on a
+// schema.
+type schemaPolicy struct {
+	role            string
+	create          bool
+	createWithGrant bool
+	usage           bool
+	usageWithGrant  bool
+}
+
+// readSchemaPolicies returns the explicit, non-default grants on schemaName,
+// excluding owner: ownership privileges are implicit and not a regular
+// grantee, so they must never be compared against (or revoked via) the
+// policy block.
+func readSchemaPolicies(db QueryAble, schemaName, owner string) (map[string]schemaPolicy, error) {
+	rows, err := db.Query(getSchemaPoliciesQuery, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema privileges: %w", err)
+	}
+	defer rows.Close()
+
+	policies := map[string]schemaPolicy{}
+	for rows.Next() {
+		var role, privilege string
+		var grantable bool
+
+		if err := rows.Scan(&role, &privilege, &grantable); err != nil {
+			return nil, fmt.Errorf("error scanning schema privilege row: %w", err)
+		}
+
+		if strings.EqualFold(role, owner) {
+			continue
+		}
+
+		policy := policies[role]
+		policy.role = role
+		switch privilege {
+		case "CREATE":
+			policy.create = true
+			policy.createWithGrant = grantable
+		case "USAGE":
+			policy.usage = true
+			policy.usageWithGrant = grantable
+		}
+		policies[role] = policy
+	}
+
+	return policies, rows.Err()
+}
+
+func schemaPoliciesFromSet(set *schema.Set) map[string]schemaPolicy {
+	policies := map[string]schemaPolicy{}
+	for _, raw := range set.List() {
+		p := raw.(map[string]interface{})
+		policy := schemaPolicy{
+			role:            p[schemaPolicyRoleAttr].(string),
+			create:          p[schemaPolicyCreateAttr].(bool),
+			createWithGrant: p[schemaPolicyCreateWithGrantAttr].(bool),
+			usage:           p[schemaPolicyUsageAttr].(bool),
+			usageWithGrant:  p[schemaPolicyUsageWithGrantAttr].(bool),
+		}
+		policies[policy.role] = policy
+	}
+	return policies
+}
+
+func schemaPoliciesToSet(policies map[string]schemaPolicy) []interface{} {
+	result := make([]interface{}, 0, len(policies))
+	for _, policy := range policies {
+		result = append(result, map[string]interface{}{
+			schemaPolicyRoleAttr:            policy.role,
+			schemaPolicyCreateAttr:          policy.create,
+			schemaPolicyCreateWithGrantAttr: policy.createWithGrant,
+			schemaPolicyUsageAttr:           policy.usage,
+			schemaPolicyUsageWithGrantAttr:  policy.usageWithGrant,
+		})
+	}
+	return result
+}
+
+// setSchemaPolicies diffs the desired policy set against oldPolicies and
+// issues only the necessary GRANT/REVOKE statements, rather than revoking
+// everything and reapplying the full desired state.
+func setSchemaPolicies(txn *sql.Tx, d *schema.ResourceData, oldPolicies map[string]schemaPolicy) error {
+	schemaName := d.Get(schemaNameAttr).(string)
+	owner := d.Get(schemaOwnerAttr).(string)
+	newPolicies := schemaPoliciesFromSet(d.Get(schemaPolicyAttr).(*schema.Set))
+	for role := range newPolicies {
+		if strings.EqualFold(role, owner) {
+			delete(newPolicies, role)
+		}
+	}
+
+	for role, oldPolicy := range oldPolicies {
+		newPolicy, stillPresent := newPolicies[role]
+		if !stillPresent {
+			newPolicy = schemaPolicy{role: role}
+		}
+		if err := diffSchemaPolicy(txn, schemaName, oldPolicy, newPolicy); err != nil {
+			return err
+		}
+	}
+
+	for role, newPolicy := range newPolicies {
+		if _, alreadyHandled := oldPolicies[role]; alreadyHandled {
+			continue
+		}
+		if err := diffSchemaPolicy(txn, schemaName, schemaPolicy{role: role}, newPolicy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func diffSchemaPolicy(txn *sql.Tx, schemaName string, old, updated schemaPolicy) error {
+	privileges := []struct {
+		name            string
+		hadGrant        bool
+		wantGrant       bool
+		hadGrantOption  bool
+		wantGrantOption bool
+	}{
+		{"CREATE", old.create, updated.create, old.createWithGrant, updated.createWithGrant},
+		{"USAGE", old.usage, updated.usage, old.usageWithGrant, updated.usageWithGrant},
+	}
+
+	for _, p := range privileges {
+		hadEffective := p.hadGrant || p.hadGrantOption
+		wantEffective := p.wantGrant || p.wantGrantOption
+
+		switch {
+		case hadEffective && !wantEffective:
+			if err := execSchemaGrant(txn, "REVOKE", p.name, schemaName, updated.role, false); err != nil {
+				return err
+			}
+		case !hadEffective && wantEffective:
+			if err := execSchemaGrant(txn, "GRANT", p.name, schemaName, updated.role, p.wantGrantOption); err != nil {
+				return err
+			}
+		case hadEffective && wantEffective && p.hadGrantOption != p.wantGrantOption:
+			if err := execSchemaGrant(txn, "REVOKE", p.name, schemaName, updated.role, false); err != nil {
+				return err
+			}
+			if err := execSchemaGrant(txn, "GRANT", p.name, schemaName, updated.role, p.wantGrantOption); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func execSchemaGrant(txn *sql.Tx, verb, privilege, schemaName, role string, withGrantOption bool) error {
+	var query string
+	switch verb {
+	case "GRANT":
+		query = fmt.Sprintf("GRANT %s ON SCHEMA %s TO %s", privilege, pq.QuoteIdentifier(schemaName), schemaGranteeSQL(role))
+		if withGrantOption {
+			query += " WITH GRANT OPTION"
+		}
+	case "REVOKE":
+		query = fmt.Sprintf("REVOKE %s ON SCHEMA %s FROM %s", privilege, pq.QuoteIdentifier(schemaName), schemaGranteeSQL(role))
+	default:
+		return fmt.Errorf("unknown grant verb %q", verb)
+	}
+
+	log.Println(query)
+	if _, err := txn.Exec(query); err != nil {
+		return fmt.Errorf("could not %s %s on schema %q to/from %q: %w", strings.ToLower(verb), privilege, schemaName, role, err)
+	}
+	return nil
+}
+
+func schemaGranteeSQL(role string) string {
+	if strings.EqualFold(role, schemaPublicRole) {
+		return "PUBLIC"
+	}
+	return pq.QuoteIdentifier(role)
+}